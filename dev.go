@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// reloadBroadcaster fans out a reload signal to every connected /events
+// client.
+type reloadBroadcaster struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]bool
+}
+
+func newReloadBroadcaster() *reloadBroadcaster {
+	return &reloadBroadcaster{clients: make(map[chan struct{}]bool)}
+}
+
+func (b *reloadBroadcaster) subscribe() chan struct{} {
+	ch := make(chan struct{}, 1)
+	b.mu.Lock()
+	b.clients[ch] = true
+	b.mu.Unlock()
+	return ch
+}
+
+func (b *reloadBroadcaster) unsubscribe(ch chan struct{}) {
+	b.mu.Lock()
+	delete(b.clients, ch)
+	b.mu.Unlock()
+	close(ch)
+}
+
+func (b *reloadBroadcaster) broadcast() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// handleEvents is the SSE endpoint the browser reload script in
+// base.tpl.html connects to in dev mode.
+func (s *Server) handleEvents(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch := s.reloads.subscribe()
+	defer s.reloads.unsubscribe(ch)
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-ch:
+			fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// watchAndReindex watches s.cfg.BaseDir and tpl/ for changes, keeping the
+// search index and connected browsers up to date until ctx is cancelled.
+// It is only started in dev mode.
+func (s *Server) watchAndReindex(ctx context.Context) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		s.log.Error("could not start filesystem watcher", "error", err)
+		return
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(s.cfg.BaseDir); err != nil {
+		s.log.Error("could not watch BaseDir", "dir", s.cfg.BaseDir, "error", err)
+		return
+	}
+	if err := watcher.Add("tpl"); err != nil {
+		s.log.Warn("could not watch tpl/", "error", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if strings.HasPrefix(filepath.ToSlash(event.Name), "tpl/") {
+				s.reloads.broadcast()
+				continue
+			}
+			if !strings.HasSuffix(event.Name, ".yaml") {
+				continue
+			}
+
+			filename := filepath.Base(event.Name)
+			if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+				if err := s.idx.DeleteOne(ctx, indexID(filename)); err != nil {
+					s.log.Error("failed to remove from index", "file", filename, "error", err)
+				}
+			} else if err := s.reindexFile(ctx, filename); err != nil {
+				s.log.Error("failed to reindex", "file", filename, "error", err)
+			}
+			s.reloads.broadcast()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			s.log.Error("watcher error", "error", err)
+		}
+	}
+}
+
+// reindexFile re-parses a single ADR file and updates its entry in the
+// search index.
+func (s *Server) reindexFile(ctx context.Context, filename string) error {
+	info, err := os.Stat(filepath.Join(s.cfg.BaseDir, filename))
+	if err != nil {
+		return err
+	}
+
+	doc, err := s.loadIndexDocument(filename)
+	if err != nil {
+		return err
+	}
+
+	return s.idx.UpdateOne(ctx, indexID(filename), doc, info.ModTime())
+}
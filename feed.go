@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/f-ewald/adr/internal/atom"
+	"net/http"
+	"sort"
+)
+
+// buildFeed loads every ADR and turns it into an atom.Feed sorted by Date
+// descending, ready to be rendered as either Atom or RSS.
+func (s *Server) buildFeed(ctx context.Context) (atom.Feed, error) {
+	docs, err := s.loadDocuments(ctx)
+	if err != nil {
+		return atom.Feed{}, err
+	}
+
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].Date.After(docs[j].Date)
+	})
+
+	feed := atom.Feed{
+		Title:  "Architecture Decision Records",
+		Link:   s.cfg.Domain,
+		Domain: s.cfg.Domain,
+	}
+	if len(docs) > 0 {
+		feed.Updated = docs[0].Date
+	}
+	for _, d := range docs {
+		specific := fmt.Sprintf("adr-%04d", d.Number)
+		feed.Entries = append(feed.Entries, atom.Entry{
+			ID:       atom.MakeTagURI(s.cfg.Domain, d.Date, specific),
+			Title:    d.Title,
+			Link:     s.cfg.Domain + "/" + d.Filename,
+			Updated:  d.Date,
+			Category: d.Status,
+			HTML:     string(renderMarkdown([]byte(d.Body))),
+		})
+	}
+	return feed, nil
+}
+
+// handleAtomFeed serves the decision records as an Atom feed.
+func (s *Server) handleAtomFeed(w http.ResponseWriter, req *http.Request) {
+	feed, err := s.buildFeed(req.Context())
+	if err != nil {
+		panic(err)
+	}
+	body, err := feed.ToAtomXML()
+	if err != nil {
+		panic(err)
+	}
+	w.Header().Set("Content-Type", "application/atom+xml")
+	_, _ = w.Write(body)
+}
+
+// handleRSSFeed serves the decision records as an RSS feed.
+func (s *Server) handleRSSFeed(w http.ResponseWriter, req *http.Request) {
+	feed, err := s.buildFeed(req.Context())
+	if err != nil {
+		panic(err)
+	}
+	body, err := feed.ToRSSXML()
+	if err != nil {
+		panic(err)
+	}
+	w.Header().Set("Content-Type", "application/rss+xml")
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,129 @@
+// Package atom renders Atom and RSS feeds of decision records.
+package atom
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// Entry is a single feed item.
+type Entry struct {
+	ID       string
+	Title    string
+	Link     string
+	Updated  time.Time
+	Category string
+	HTML     string
+}
+
+// Feed describes the channel-level metadata shared by Atom and RSS output.
+type Feed struct {
+	Title   string
+	Link    string
+	Domain  string
+	Updated time.Time
+	Entries []Entry
+}
+
+// MakeTagURI builds a `tag:` URI for an entry, e.g.
+// "tag:example.com,2024:adr-0007". Tag URIs are stable identifiers that
+// survive the entry's link changing, per RFC 4151.
+func MakeTagURI(domain string, date time.Time, specific string) string {
+	return fmt.Sprintf("tag:%s,%d:%s", domain, date.Year(), specific)
+}
+
+type atomXML struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	Link    atomLinkXML `xml:"link"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntryXML `xml:"entry"`
+}
+
+type atomLinkXML struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomEntryXML struct {
+	Title    string      `xml:"title"`
+	Link     atomLinkXML `xml:"link"`
+	ID       string      `xml:"id"`
+	Updated  string      `xml:"updated"`
+	Category atomCategoryXML `xml:"category"`
+	Content  atomContentXML  `xml:"content"`
+}
+
+type atomCategoryXML struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContentXML struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",cdata"`
+}
+
+// ToAtomXML renders the feed as an Atom 1.0 document.
+func (f Feed) ToAtomXML() ([]byte, error) {
+	doc := atomXML{
+		Title:   f.Title,
+		Link:    atomLinkXML{Href: f.Link},
+		ID:      MakeTagURI(f.Domain, f.Updated, "feed"),
+		Updated: f.Updated.Format(time.RFC3339),
+	}
+	for _, e := range f.Entries {
+		doc.Entries = append(doc.Entries, atomEntryXML{
+			Title:    e.Title,
+			Link:     atomLinkXML{Href: e.Link},
+			ID:       e.ID,
+			Updated:  e.Updated.Format(time.RFC3339),
+			Category: atomCategoryXML{Term: e.Category},
+			Content:  atomContentXML{Type: "html", Body: e.HTML},
+		})
+	}
+	return xml.MarshalIndent(doc, "", "  ")
+}
+
+type rssXML struct {
+	XMLName xml.Name  `xml:"rss"`
+	Version string    `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title string    `xml:"title"`
+	Link  string    `xml:"link"`
+	Items []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Category    string `xml:"category"`
+	Description string `xml:"description"`
+}
+
+// ToRSSXML renders the feed as an RSS 2.0 document.
+func (f Feed) ToRSSXML() ([]byte, error) {
+	doc := rssXML{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title: f.Title,
+			Link:  f.Link,
+		},
+	}
+	for _, e := range f.Entries {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			GUID:        e.ID,
+			PubDate:     e.Updated.Format(time.RFC1123Z),
+			Category:    e.Category,
+			Description: e.HTML,
+		})
+	}
+	return xml.MarshalIndent(doc, "", "  ")
+}
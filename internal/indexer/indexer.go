@@ -0,0 +1,207 @@
+// Package indexer owns the Bleve search index: how it is mapped, opened
+// from disk (or built in-memory), and kept up to date as ADRs change.
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+)
+
+// Indexer wraps a Bleve index and tracks, per document id, the modtime of
+// the file it was built from, so Rebuild can skip files that haven't
+// changed, plus the full set of ids it indexed last time, so Rebuild can
+// also notice when one has disappeared and needs deleting.
+type Indexer struct {
+	Index bleve.Index
+}
+
+// BuildMapping configures per-field analyzers so field-scoped and range
+// queries over status, number and date behave as expected. Bleve walks
+// indexed structs by their json tag, not their Go field name (Document's
+// Status field, say, is indexed as "status"), so every path added here
+// must be the json name, not the Go one. Bleve also skips fields tagged
+// json:"-", as Document's Body is, so the "body" mapping below matches no
+// field and ADR bodies are not indexed or searchable.
+func BuildMapping() mapping.IndexMapping {
+	keywordField := bleve.NewTextFieldMapping()
+	keywordField.Analyzer = "keyword"
+
+	numberField := bleve.NewNumericFieldMapping()
+	dateField := bleve.NewDateTimeFieldMapping()
+	textField := bleve.NewTextFieldMapping()
+
+	docMapping := bleve.NewDocumentMapping()
+	docMapping.AddFieldMappingsAt("status", keywordField)
+	docMapping.AddFieldMappingsAt("number", numberField)
+	docMapping.AddFieldMappingsAt("item", keywordField)
+	docMapping.AddFieldMappingsAt("title", textField)
+	docMapping.AddFieldMappingsAt("body", textField)
+	docMapping.AddFieldMappingsAt("date", dateField)
+
+	indexMapping := bleve.NewIndexMapping()
+	indexMapping.DefaultMapping = docMapping
+	return indexMapping
+}
+
+// Open opens the persistent index at dir, creating it if it doesn't exist
+// yet. An empty dir opens a transient in-memory index instead, which is
+// useful for tests and for corpora too small to bother persisting.
+func Open(dir string) (*Indexer, error) {
+	if dir == "" {
+		idx, err := bleve.New("", BuildMapping())
+		if err != nil {
+			return nil, err
+		}
+		return &Indexer{Index: idx}, nil
+	}
+
+	idx, err := bleve.Open(dir)
+	if err == bleve.ErrorIndexPathDoesNotExist {
+		idx, err = bleve.New(dir, BuildMapping())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Indexer{Index: idx}, nil
+}
+
+func metaKey(id string) string {
+	return "_meta:" + id
+}
+
+// knownIDsKey is the internal key Rebuild uses to remember which ids it
+// indexed last time, since Bleve's internal KV store has no way to list its
+// own keys. Without it, Rebuild would have no way to notice an id has
+// disappeared from sources and needs deleting.
+var knownIDsKey = []byte("_known_ids")
+
+func (ix *Indexer) knownIDs() (map[string]bool, error) {
+	raw, err := ix.Index.GetInternal(knownIDsKey)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool)
+	if raw == nil {
+		return known, nil
+	}
+	for _, id := range strings.Split(string(raw), "\n") {
+		if id != "" {
+			known[id] = true
+		}
+	}
+	return known, nil
+}
+
+func (ix *Indexer) setKnownIDs(ids map[string]bool) error {
+	list := make([]string, 0, len(ids))
+	for id := range ids {
+		list = append(list, id)
+	}
+	return ix.Index.SetInternal(knownIDsKey, []byte(strings.Join(list, "\n")))
+}
+
+// ModTime returns the modtime recorded for id the last time it was indexed.
+func (ix *Indexer) ModTime(id string) (time.Time, bool) {
+	raw, err := ix.Index.GetInternal([]byte(metaKey(id)))
+	if err != nil || raw == nil {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, string(raw))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// UpdateOne indexes doc under id and records modTime in the sidecar _meta
+// entry used by Rebuild to skip unchanged files.
+func (ix *Indexer) UpdateOne(_ context.Context, id string, doc interface{}, modTime time.Time) error {
+	if err := ix.Index.Index(id, doc); err != nil {
+		return err
+	}
+	return ix.Index.SetInternal([]byte(metaKey(id)), []byte(modTime.Format(time.RFC3339Nano)))
+}
+
+// DeleteOne removes id and its sidecar _meta entry from the index.
+func (ix *Indexer) DeleteOne(_ context.Context, id string) error {
+	if err := ix.Index.Delete(id); err != nil {
+		return err
+	}
+	return ix.Index.DeleteInternal([]byte(metaKey(id)))
+}
+
+// Source describes a single file Rebuild should consider indexing.
+type Source struct {
+	ID      string
+	ModTime time.Time
+}
+
+// Load parses a Source into the document that should be indexed for it.
+type Load func(src Source) (doc interface{}, err error)
+
+// Rebuild indexes every source that has no recorded modtime yet or whose
+// recorded modtime is older than the one reported by the filesystem,
+// skipping the rest, and then deletes any
+// previously indexed id that no longer appears in sources at all. It
+// returns how many documents were (re)indexed.
+func (ix *Indexer) Rebuild(ctx context.Context, sources []Source, load Load) (int, error) {
+	previousIDs, err := ix.knownIDs()
+	if err != nil {
+		return 0, fmt.Errorf("load known ids: %w", err)
+	}
+
+	current := make(map[string]bool, len(sources))
+	var indexed int
+	for _, src := range sources {
+		select {
+		case <-ctx.Done():
+			return indexed, ctx.Err()
+		default:
+		}
+		current[src.ID] = true
+
+		if modTime, ok := ix.ModTime(src.ID); ok && !src.ModTime.After(modTime) {
+			continue
+		}
+
+		doc, err := load(src)
+		if err != nil {
+			return indexed, fmt.Errorf("load %s: %w", src.ID, err)
+		}
+		if err := ix.UpdateOne(ctx, src.ID, doc, src.ModTime); err != nil {
+			return indexed, fmt.Errorf("index %s: %w", src.ID, err)
+		}
+		indexed++
+	}
+
+	for id := range previousIDs {
+		if current[id] {
+			continue
+		}
+		if err := ix.DeleteOne(ctx, id); err != nil {
+			return indexed, fmt.Errorf("delete stale %s: %w", id, err)
+		}
+	}
+
+	if err := ix.setKnownIDs(current); err != nil {
+		return indexed, fmt.Errorf("save known ids: %w", err)
+	}
+	return indexed, nil
+}
+
+// Search runs req against the index. It takes ctx for cancellation even
+// though the current Bleve version does not accept one directly, so
+// callers can propagate cancellation without a future signature change.
+func (ix *Indexer) Search(ctx context.Context, req *bleve.SearchRequest) (*bleve.SearchResult, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	return ix.Index.Search(req)
+}
@@ -2,8 +2,12 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/adrg/frontmatter"
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/f-ewald/adr/internal/indexer"
 	"github.com/fatih/color"
 	"github.com/gomarkdown/markdown"
 	"github.com/gomarkdown/markdown/html"
@@ -11,60 +15,169 @@ import (
 	"html/template"
 	"io/ioutil"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
-var (
-	searchIndex bleve.Index
-)
+// pageTemplates maps each page's root template name to the set of files it
+// needs parsed alongside it. It is the single place that knows how the tpl/
+// files compose, used both to cache templates at startup and to re-parse
+// them on every request in --dev mode.
+var pageTemplates = map[string][]string{
+	"list.tpl.html":    {"tpl/styles.css", "tpl/base.tpl.html", "tpl/list.tpl.html"},
+	"detail.tpl.html":  {"tpl/styles.css", "tpl/base.tpl.html", "tpl/detail.tpl.html"},
+	"results.tpl.html": {"tpl/styles.css", "tpl/base.tpl.html", "tpl/results.tpl.html"},
+}
+
+// Server holds everything a request handler needs, replacing the package
+// globals (searchIndex, ad-hoc getConfig() calls) the handlers used to
+// close over. It is constructed once in serve() and its methods are
+// registered directly on the mux, which also lets tests build a Server
+// against a tempdir corpus without touching process-wide state.
+type Server struct {
+	cfg  *Config
+	idx  *indexer.Indexer
+	tpls map[string]*template.Template
+	log  *slog.Logger
+	dev  bool
+
+	reloads *reloadBroadcaster
+}
+
+// NewServer opens the search index and caches templates. In dev mode,
+// templates are instead re-parsed from disk on every request by
+// (*Server).template, so caching here only happens for the production path.
+func NewServer(cfg *Config, dev bool, logger *slog.Logger) (*Server, error) {
+	idx, err := indexer.Open(cfg.IndexDir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		cfg:     cfg,
+		idx:     idx,
+		log:     logger,
+		dev:     dev,
+		reloads: newReloadBroadcaster(),
+	}
+
+	if !dev {
+		tpls := make(map[string]*template.Template, len(pageTemplates))
+		for name, files := range pageTemplates {
+			tpl, err := template.New(name).ParseFS(fs, files...)
+			if err != nil {
+				return nil, err
+			}
+			tpls[name] = tpl
+		}
+		s.tpls = tpls
+	}
+
+	if err := s.rebuildIndex(context.Background()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// template returns the named page template, re-parsing it from disk first
+// if the server is running in --dev mode so template edits take effect
+// without a restart.
+func (s *Server) template(name string) (*template.Template, error) {
+	if s.dev {
+		return template.New(name).ParseFiles(pageTemplates[name]...)
+	}
+	return s.tpls[name], nil
+}
 
 // Document represents a single decision record.
 type Document struct {
-	Filename string    `json:"-" yaml:"-"`
+	// Filename is exposed as "item" in JSON, since that's the path segment
+	// GET /api/v1/docs/{item} expects; it has no business in the YAML
+	// frontmatter, which is keyed by the file it's stored in.
+	Filename string    `json:"item" yaml:"-"`
 	Number   int       `json:"number" yaml:"number"`
 	Title    string    `json:"title" yaml:"title"`
 	Date     time.Time `json:"date" yaml:"date"`
 	Status   string    `json:"status" yaml:"status"`
 	Body     string    `json:"-" yaml:"-"`
+
+	// Supersedes holds the filename of the ADR this one replaces, and
+	// SupersededBy the filename of the ADR that replaced this one. Both are
+	// set on a document that replaced one ADR and was later replaced itself.
+	Supersedes   string `json:"supersedes,omitempty" yaml:"supersedes,omitempty"`
+	SupersededBy string `json:"superseded_by,omitempty" yaml:"superseded_by,omitempty"`
 }
 
+// docFromMap converts a search hit's stored fields, keyed by the json names
+// BuildMapping indexes under, back into a Document for rendering search
+// results. Body is left empty, since bodies are not stored in the index.
 func docFromMap(m map[string]interface{}) Document {
-	number := m["number"].(float64)
-	return Document{
-		Filename: "",
-		Number:   int(number),
-		Title:    m["title"].(string),
-		Date:     time.Time{},
-		Status:   m["status"].(string),
-		Body:     "",
+	d := Document{
+		Number: int(m["number"].(float64)),
+		Title:  m["title"].(string),
+		Status: m["status"].(string),
+	}
+	if item, ok := m["item"].(string); ok {
+		d.Filename = item
+	}
+	if dateStr, ok := m["date"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, dateStr); err == nil {
+			d.Date = t
+		}
 	}
+	return d
 }
 
 // doNothing returns an empty response.
 // This is used for example to return an empty favicon.
 func doNothing(w http.ResponseWriter, r *http.Request) {}
 
-// handleList is the entry page into the application.
-func handleList(w http.ResponseWriter, req *http.Request) {
-	w.Header().Add("Content-Type", "text/html")
-	tpl, err := template.New("list.tpl.html").ParseFS(fs,
-		"tpl/styles.css", "tpl/base.tpl.html", "tpl/list.tpl.html")
-	if err != nil {
+// wantsJSON reports whether the request should be answered with JSON rather
+// than HTML, either because it hit an /api/v1/ route or because it asked for
+// application/json via content negotiation.
+func wantsJSON(req *http.Request) bool {
+	if strings.HasPrefix(req.URL.Path, "/api/") {
+		return true
+	}
+	return strings.Contains(req.Header.Get("Accept"), "application/json")
+}
+
+// writeJSON encodes v as the JSON response body, or panics like the rest of
+// this package's handlers on failure.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
 		panic(err)
 	}
+}
+
+// renderMarkdown renders a document's raw markdown body to HTML.
+func renderMarkdown(body []byte) template.HTML {
+	renderer := html.NewRenderer(html.RendererOptions{Flags: html.SkipHTML | html.Smartypants})
+	return template.HTML(markdown.ToHTML(body, nil, renderer))
+}
 
-	cfg := getConfig()
-	rawFiles, err := ioutil.ReadDir(cfg.BaseDir)
+// loadDocuments reads and parses every ADR in s.cfg.BaseDir. It is the
+// single source of data for both the HTML list page and the JSON docs
+// endpoint.
+func (s *Server) loadDocuments(ctx context.Context) ([]Document, error) {
+	rawFiles, err := ioutil.ReadDir(s.cfg.BaseDir)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	docs := make([]Document, 0)
 	for i := 0; i < len(rawFiles); i++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		if rawFiles[i].IsDir() {
 			// Ignore subdirectories
 			continue
@@ -73,19 +186,17 @@ func handleList(w http.ResponseWriter, req *http.Request) {
 			// Ignore all rawFiles not ending in .md
 			continue
 		}
-		filename := strings.TrimSuffix(rawFiles[i].Name(), ".yaml")
-		filename = strings.Join(strings.Split(filename, "-"), " ")
 
-		f, err := os.Open(filepath.Join(cfg.BaseDir, rawFiles[i].Name()))
+		f, err := os.Open(filepath.Join(s.cfg.BaseDir, rawFiles[i].Name()))
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
 
 		var d Document
 		body, err := frontmatter.Parse(f, &d)
 		if err != nil {
 			_ = f.Close()
-			panic(err)
+			return nil, err
 		}
 		_ = f.Close()
 		d.Filename = rawFiles[i].Name()
@@ -93,11 +204,54 @@ func handleList(w http.ResponseWriter, req *http.Request) {
 
 		docs = append(docs, d)
 	}
+	return docs, nil
+}
+
+// loadDocument reads and parses a single ADR by its filename.
+func (s *Server) loadDocument(ctx context.Context, item string) (Document, error) {
+	if ctx.Err() != nil {
+		return Document{}, ctx.Err()
+	}
 
+	f, err := os.Open(filepath.Join(s.cfg.BaseDir, item))
+	if err != nil {
+		return Document{}, err
+	}
+	defer f.Close()
+
+	var d Document
+	body, err := frontmatter.Parse(f, &d)
+	if err != nil {
+		return Document{}, err
+	}
+	d.Filename = item
+	d.Body = string(body)
+	return d, nil
+}
+
+// handleList is the entry page into the application.
+func (s *Server) handleList(w http.ResponseWriter, req *http.Request) {
+	docs, err := s.loadDocuments(req.Context())
+	if err != nil {
+		panic(err)
+	}
+
+	if wantsJSON(req) {
+		writeJSON(w, docs)
+		return
+	}
+
+	w.Header().Add("Content-Type", "text/html")
+	tpl, err := s.template("list.tpl.html")
+	if err != nil {
+		panic(err)
+	}
 	err = tpl.Execute(w, struct {
 		Files []Document
+		Dev   bool
 	}{
 		Files: docs,
+		Dev:   s.dev,
 	})
 	if err != nil {
 		panic(err)
@@ -105,150 +259,428 @@ func handleList(w http.ResponseWriter, req *http.Request) {
 }
 
 // handleDetail shows the details for a document.
-func handleDetail(w http.ResponseWriter, req *http.Request) {
+func (s *Server) handleDetail(w http.ResponseWriter, req *http.Request) {
 	item, ok := mux.Vars(req)["item"]
 	if !ok {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
 
-	cfg := getConfig()
-	f, err := os.Open(filepath.Join(cfg.BaseDir, item))
+	d, err := s.loadDocument(req.Context(), item)
 	if err != nil {
 		panic(err)
 	}
-	var d Document
-	body, err := frontmatter.Parse(f, &d)
-	if err != nil {
-		_ = f.Close()
-		panic(err)
-	}
-	_ = f.Close()
-	d.Body = string(body)
+	b := renderMarkdown([]byte(d.Body))
 
-	// Render Markdown document
-	renderer := html.NewRenderer(html.RendererOptions{Flags: html.SkipHTML | html.Smartypants})
-	b := markdown.ToHTML(body, nil, renderer)
+	if wantsJSON(req) {
+		writeJSON(w, struct {
+			Document
+			HTML string `json:"html"`
+			Body string `json:"body"`
+		}{
+			Document: d,
+			HTML:     string(b),
+			Body:     d.Body,
+		})
+		return
+	}
 
-	tpl, err := template.New("detail.tpl.html").ParseFS(fs,
-		"tpl/styles.css", "tpl/base.tpl.html", "tpl/detail.tpl.html")
+	tpl, err := s.template("detail.tpl.html")
 	if err != nil {
 		panic(err)
 	}
 	err = tpl.Execute(w, struct {
 		Doc  Document
 		Body template.HTML
+		Dev  bool
 	}{
 		Doc:  d,
-		Body: template.HTML(b),
+		Body: b,
+		Dev:  s.dev,
 	})
 	if err != nil {
 		panic(err)
 	}
 }
 
-// handleSearch shows the search results if any.
-func handleSearch(w http.ResponseWriter, req *http.Request) {
-	q := req.URL.Query().Get("q")
-	query := bleve.NewFuzzyQuery(q)
-	searchRequest := bleve.NewSearchRequest(query)
+// FacetTerm is a single bucket of a facet, e.g. a status value and how many
+// documents carry it.
+type FacetTerm struct {
+	Term  string
+	Count int
+}
+
+// Facets holds the facet buckets rendered in the search results sidebar.
+type Facets struct {
+	Status []FacetTerm
+	Year   []FacetTerm
+}
+
+func facetTerms(result *search.FacetResult) []FacetTerm {
+	if result == nil {
+		return nil
+	}
+	terms := make([]FacetTerm, 0, len(result.Terms.Terms()))
+	for _, t := range result.Terms.Terms() {
+		terms = append(terms, FacetTerm{Term: t.Term, Count: t.Count})
+	}
+	return terms
+}
+
+// facetDateRanges converts a date-range facet result into the same
+// FacetTerm shape facetTerms produces for term facets. Unlike a plain terms
+// facet, faceting a datetime field requires explicit named ranges (see
+// buildSearchRequest), so the buckets come back under DateRanges rather
+// than Terms.
+func facetDateRanges(result *search.FacetResult) []FacetTerm {
+	if result == nil {
+		return nil
+	}
+	terms := make([]FacetTerm, 0, len(result.DateRanges))
+	for _, r := range result.DateRanges {
+		terms = append(terms, FacetTerm{Term: r.Name, Count: r.Count})
+	}
+	return terms
+}
+
+// dateRangePattern matches the date:start..end range syntax used by search,
+// e.g. "date:2023-01-01..2024-01-01". Bleve's query string grammar has no
+// ".." operator, so extractDateRange pulls this out of the query text
+// before the rest of it reaches bleve.NewQueryStringQuery.
+var dateRangePattern = regexp.MustCompile(`(?i)\bdate:(\d{4}-\d{2}-\d{2})\.\.(\d{4}-\d{2}-\d{2})\b`)
+
+// extractDateRange pulls a date:start..end clause out of q, returning the
+// query text with the clause removed and the equivalent DateRangeQuery. ok
+// is false if q has no such clause, or its dates don't parse, in which case
+// remaining is just q unchanged.
+func extractDateRange(q string) (remaining string, rangeQuery query.Query, ok bool) {
+	loc := dateRangePattern.FindStringSubmatchIndex(q)
+	if loc == nil {
+		return q, nil, false
+	}
+	start, err := time.Parse("2006-01-02", q[loc[2]:loc[3]])
+	if err != nil {
+		return q, nil, false
+	}
+	end, err := time.Parse("2006-01-02", q[loc[4]:loc[5]])
+	if err != nil {
+		return q, nil, false
+	}
+	// The end date is inclusive of the whole day it names.
+	end = end.Add(24*time.Hour - time.Nanosecond)
+
+	dq := bleve.NewDateRangeQuery(start, end)
+	dq.SetField("date")
+
+	remaining = strings.TrimSpace(q[:loc[0]] + " " + q[loc[1]:])
+	return remaining, dq, true
+}
+
+// yearRangeQuery returns a query matching documents dated anywhere in the
+// calendar year yearStr (e.g. "2023"), and false if yearStr isn't one.
+func yearRangeQuery(yearStr string) (query.Query, bool) {
+	year, err := strconv.Atoi(yearStr)
+	if err != nil {
+		return nil, false
+	}
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	dq := bleve.NewDateRangeQuery(start, end)
+	dq.SetField("date")
+	return dq, true
+}
+
+// indexedYears returns the distinct calendar years present among the
+// indexed ADRs, sorted ascending, so buildSearchRequest can bucket the year
+// facet by the years that actually occur instead of an arbitrary fixed
+// range.
+func (s *Server) indexedYears(ctx context.Context) ([]int, error) {
+	docs, err := s.loadDocuments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[int]bool, len(docs))
+	for _, d := range docs {
+		seen[d.Date.Year()] = true
+	}
+	years := make([]int, 0, len(seen))
+	for y := range seen {
+		years = append(years, y)
+	}
+	sort.Ints(years)
+	return years, nil
+}
+
+// buildSearchRequest turns the raw query string and paging/sort/facet
+// parameters into a bleve.SearchRequest. The query string itself is handed
+// to bleve's query string parser, which understands field-scoped terms
+// (status:accepted) and phrases, except for date:start..end ranges, which
+// bleve's grammar has no syntax for and which extractDateRange pre-parses
+// into a DateRangeQuery instead. The status and year facet links rendered
+// in results.tpl.html are applied here too, as additional filters read
+// straight off the query parameters of the same name.
+func (s *Server) buildSearchRequest(ctx context.Context, req *http.Request) (*bleve.SearchRequest, error) {
+	params := req.URL.Query()
+	q := params.Get("q")
+
+	var clauses []query.Query
+	if remaining, dateQuery, ok := extractDateRange(q); ok {
+		clauses = append(clauses, dateQuery)
+		q = remaining
+	}
+	if strings.TrimSpace(q) != "" {
+		clauses = append(clauses, bleve.NewQueryStringQuery(q))
+	}
+	if status := params.Get("status"); status != "" {
+		statusQuery := bleve.NewTermQuery(strings.ToLower(status))
+		statusQuery.SetField("status")
+		clauses = append(clauses, statusQuery)
+	}
+	if year := params.Get("year"); year != "" {
+		if yearQuery, ok := yearRangeQuery(year); ok {
+			clauses = append(clauses, yearQuery)
+		}
+	}
+
+	var finalQuery query.Query
+	switch len(clauses) {
+	case 0:
+		finalQuery = bleve.NewMatchAllQuery()
+	case 1:
+		finalQuery = clauses[0]
+	default:
+		finalQuery = bleve.NewConjunctionQuery(clauses...)
+	}
+
+	searchRequest := bleve.NewSearchRequest(finalQuery)
 	searchRequest.Fields = []string{"*"}
 	searchRequest.Highlight = bleve.NewHighlight()
-	results, err := searchIndex.Search(searchRequest)
+
+	searchRequest.From, _ = strconv.Atoi(params.Get("from"))
+	searchRequest.Size = 20
+	if size, err := strconv.Atoi(params.Get("size")); err == nil && size > 0 {
+		searchRequest.Size = size
+	}
+
+	switch params.Get("sort") {
+	case "date":
+		searchRequest.SortBy([]string{"date"})
+	case "number":
+		searchRequest.SortBy([]string{"number"})
+	case "-score":
+		searchRequest.SortBy([]string{"-_score"})
+	}
+
+	searchRequest.AddFacet("status", bleve.NewFacetRequest("status", 10))
+
+	years, err := s.indexedYears(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(years) > 0 {
+		yearFacet := bleve.NewFacetRequest("date", len(years))
+		for _, y := range years {
+			start := time.Date(y, time.January, 1, 0, 0, 0, 0, time.UTC)
+			end := time.Date(y+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+			yearFacet.AddDateTimeRange(strconv.Itoa(y), start, end)
+		}
+		searchRequest.AddFacet("year", yearFacet)
+	}
+
+	return searchRequest, nil
+}
+
+// handleSearch shows the search results if any.
+func (s *Server) handleSearch(w http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query().Get("q")
+	searchRequest, err := s.buildSearchRequest(req.Context(), req)
+	if err != nil {
+		panic(err)
+	}
+	results, err := s.idx.Search(req.Context(), searchRequest)
 	if err != nil {
 		panic(err)
 	}
 
 	docs := make([]Document, 0)
+	highlights := make(map[string][]string, len(results.Hits))
 	for _, hit := range results.Hits {
 		docs = append(docs, docFromMap(hit.Fields))
+		for _, fragments := range hit.Fragments {
+			highlights[hit.ID] = append(highlights[hit.ID], fragments...)
+		}
 	}
 
-	tpl, err := template.New("results.tpl.html").ParseFS(fs,
-		"tpl/styles.css", "tpl/base.tpl.html", "tpl/results.tpl.html")
+	facets := Facets{
+		Status: facetTerms(results.Facets["status"]),
+		Year:   facetDateRanges(results.Facets["year"]),
+	}
+
+	if wantsJSON(req) {
+		writeJSON(w, struct {
+			Total      uint64              `json:"total"`
+			Query      string              `json:"query"`
+			Hits       []Document          `json:"hits"`
+			Highlights map[string][]string `json:"highlights"`
+			Facets     Facets              `json:"facets"`
+		}{
+			Total:      results.Total,
+			Query:      q,
+			Hits:       docs,
+			Highlights: highlights,
+			Facets:     facets,
+		})
+		return
+	}
+
+	tpl, err := s.template("results.tpl.html")
 	if err != nil {
 		panic(err)
 	}
 	w.Header().Set("Content-Type", "text/html")
 	err = tpl.Execute(w, struct {
-		Count int
-		Query string
-		Docs  []Document
+		Count    int
+		Query    string
+		Docs     []Document
+		Facets   Facets
+		HasMore  bool
+		NextFrom int
+		Dev      bool
 	}{
-		Count: int(results.Total),
-		Query: q,
-		Docs:  docs,
+		Count:    int(results.Total),
+		Query:    q,
+		Docs:     docs,
+		Facets:   facets,
+		HasMore:  searchRequest.From+searchRequest.Size < int(results.Total),
+		NextFrom: searchRequest.From + searchRequest.Size,
+		Dev:      s.dev,
 	})
 	if err != nil {
 		panic(err)
 	}
 }
 
-// createIndex creates the in-memory search index that is used during runtime.
-func createIndex() error {
-	color.Green("Building search index...")
+// indexID is the id a document is stored under in the search index.
+func indexID(filename string) string {
+	return strings.ToLower(filename)
+}
 
-	// Search index in-memory
-	searchIndex, err = bleve.New("", bleve.NewIndexMapping())
+// loadIndexDocument parses the ADR at filename back into a Document, for
+// use as an indexer.Load callback.
+func (s *Server) loadIndexDocument(filename string) (interface{}, error) {
+	f, err := os.Open(filepath.Join(s.cfg.BaseDir, filename))
 	if err != nil {
-		return err
+		return nil, err
+	}
+	defer f.Close()
+
+	var d Document
+	body, err := frontmatter.Parse(f, &d)
+	if err != nil {
+		return nil, err
 	}
+	d.Body = string(body)
+	d.Filename = filename
+	return d, nil
+}
 
-	cfg := getConfig()
-	fileInfos, err := ioutil.ReadDir(cfg.BaseDir)
+// rebuildIndex brings the search index up to date with s.cfg.BaseDir,
+// skipping files whose sidecar modtime hasn't changed since the last run.
+// It is called once in NewServer and again on every tick of the background
+// refresh goroutine started in serve().
+func (s *Server) rebuildIndex(ctx context.Context) error {
+	fileInfos, err := ioutil.ReadDir(s.cfg.BaseDir)
 	if err != nil {
 		return err
 	}
-	var i int
-	for i = 0; i < len(fileInfos); i++ {
-		fileInfo := fileInfos[i]
-		if fileInfo.IsDir() {
-			continue
-		}
-		if !strings.HasSuffix(fileInfo.Name(), ".yaml") {
+
+	sources := make([]indexer.Source, 0, len(fileInfos))
+	filenames := make(map[string]string, len(fileInfos))
+	for _, fileInfo := range fileInfos {
+		if fileInfo.IsDir() || !strings.HasSuffix(fileInfo.Name(), ".yaml") {
 			continue
 		}
-		f, err := os.Open(filepath.Join(cfg.BaseDir, fileInfo.Name()))
-		if err != nil {
-			panic(err)
-		}
+		id := indexID(fileInfo.Name())
+		filenames[id] = fileInfo.Name()
+		sources = append(sources, indexer.Source{ID: id, ModTime: fileInfo.ModTime()})
+	}
 
-		var d Document
-		body, err := frontmatter.Parse(f, &d)
-		if err != nil {
-			_ = f.Close()
-			panic(err)
-		}
-		_ = f.Close()
-		d.Body = string(body)
-		d.Filename = fileInfo.Name()
+	indexed, err := s.idx.Rebuild(ctx, sources, func(src indexer.Source) (interface{}, error) {
+		return s.loadIndexDocument(filenames[src.ID])
+	})
+	if err != nil {
+		return err
+	}
+	s.log.Info("search index up to date", "indexed", indexed, "total", len(sources))
+	return nil
+}
 
-		normalizedFilename := strings.ToLower(fileInfo.Name())
-		err = searchIndex.Index(normalizedFilename, d)
-		if err != nil {
-			return err
+// indexRefreshInterval is how often the background goroutine re-scans
+// s.cfg.BaseDir for changes when the server isn't running in --dev mode.
+const indexRefreshInterval = 5 * time.Minute
+
+// runBackgroundRefresh periodically rebuilds the search index until ctx is
+// cancelled. It is the non-dev-mode counterpart to watchAndReindex: instead
+// of reacting to fsnotify events it just re-scans on a ticker.
+func (s *Server) runBackgroundRefresh(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.rebuildIndex(ctx); err != nil {
+				s.log.Error("background index refresh failed", "error", err)
+			}
 		}
 	}
-	color.Green("Search index built from %d documents.", i)
-	return nil
 }
 
 // serve is the main function that registers the routes and starts the webserver.
-func serve() {
-	// Create search index
-	err = createIndex()
+// dev enables live-reload: templates are read from disk on every request,
+// cfg.BaseDir and tpl/ are watched, and a reload is pushed to connected
+// browsers over SSE whenever either changes.
+func serve(dev bool) {
+	logger := slog.Default()
+
+	s, err := NewServer(getConfig(), dev, logger)
 	if err != nil {
 		panic(err)
 	}
 
+	bgCtx, cancelBG := context.WithCancel(context.Background())
+	if s.dev {
+		color.Yellow("Dev mode enabled: watching %s and tpl/ for changes", s.cfg.BaseDir)
+		go s.watchAndReindex(bgCtx)
+	} else {
+		go s.runBackgroundRefresh(bgCtx, indexRefreshInterval)
+	}
+
 	router := mux.NewRouter()
 
 	// Ignore favicon requests
 	router.HandleFunc("/favicon.ico", doNothing)
 
-	router.HandleFunc("/search", handleSearch)
-	router.HandleFunc("/{item}", handleDetail)
-	router.HandleFunc("/", handleList)
+	router.HandleFunc("/api/v1/search", s.handleSearch)
+	router.HandleFunc("/api/v1/docs/{item}", s.handleDetail)
+	router.HandleFunc("/api/v1/docs", s.handleList)
+
+	router.HandleFunc("/feed.atom", s.handleAtomFeed)
+	router.HandleFunc("/feed.rss", s.handleRSSFeed)
+
+	router.HandleFunc("/new", s.requireAuth(s.handleNew)).Methods(http.MethodPost)
+	router.HandleFunc("/{item}/edit", s.requireAuth(s.handleEdit)).Methods(http.MethodPost)
+	router.HandleFunc("/{item}/supersede", s.requireAuth(s.handleSupersede)).Methods(http.MethodPost)
+
+	if s.dev {
+		router.HandleFunc("/events", s.handleEvents)
+	}
+
+	router.HandleFunc("/search", s.handleSearch)
+	router.HandleFunc("/{item}", s.handleDetail)
+	router.HandleFunc("/", s.handleList)
 
 	color.Green("Starting server on port 8090")
 	srv := &http.Server{
@@ -272,6 +704,9 @@ func serve() {
 	// Block until we receive our signal.
 	<-c
 
+	// Stop the filesystem watcher / background refresh goroutine.
+	cancelBG()
+
 	// Create a deadline to wait for.
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// newTestServer builds a Server against a tempdir corpus of two ADRs, the
+// use case Server was introduced for: letting tests construct one without
+// touching process-wide state. The index is in-memory, per indexer.Open's
+// behaviour for an empty IndexDir.
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	dir := t.TempDir()
+	writeADR(t, dir, "0001-use-markdown.yaml", `---
+number: 1
+title: Use Markdown for ADR bodies
+status: accepted
+date: 2023-05-01T00:00:00Z
+---
+We will write ADRs in Markdown.
+`)
+	writeADR(t, dir, "0002-adopt-bleve.yaml", `---
+number: 2
+title: Adopt Bleve for search
+status: proposed
+date: 2024-02-10T00:00:00Z
+---
+We will index ADRs with Bleve.
+`)
+
+	cfg := &Config{BaseDir: dir}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s, err := NewServer(cfg, false, logger)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	return s
+}
+
+func writeADR(t *testing.T, dir, filename, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, filename), []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", filename, err)
+	}
+}
+
+func TestHandleListRendersEveryADR(t *testing.T) {
+	s := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	s.handleList(w, req)
+
+	body := w.Body.String()
+	for _, want := range []string{"Use Markdown for ADR bodies", "Adopt Bleve for search"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("handleList response missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestHandleDetailRendersBody(t *testing.T) {
+	s := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/0001-use-markdown.yaml", nil)
+	req = mux.SetURLVars(req, map[string]string{"item": "0001-use-markdown.yaml"})
+	w := httptest.NewRecorder()
+	s.handleDetail(w, req)
+
+	if !strings.Contains(w.Body.String(), "We will write ADRs in Markdown") {
+		t.Errorf("handleDetail response missing ADR body:\n%s", w.Body.String())
+	}
+}
+
+func TestHandleSearchFindsMatchingADR(t *testing.T) {
+	s := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/search?q=bleve", nil)
+	w := httptest.NewRecorder()
+	s.handleSearch(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, "Adopt Bleve for search") {
+		t.Errorf("handleSearch response missing matching ADR:\n%s", body)
+	}
+	if strings.Contains(body, "Use Markdown for ADR bodies") {
+		t.Errorf("handleSearch response unexpectedly matched unrelated ADR:\n%s", body)
+	}
+}
+
+// searchJSON is the shape of handleSearch's JSON response, enough of it to
+// assert on facets and totals.
+type searchJSON struct {
+	Total  uint64 `json:"total"`
+	Facets struct {
+		Status []FacetTerm
+		Year   []FacetTerm
+	} `json:"facets"`
+}
+
+func doSearch(t *testing.T, s *Server, query string) searchJSON {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/search?"+query, nil)
+	w := httptest.NewRecorder()
+	s.handleSearch(w, req)
+
+	var resp searchJSON
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode search response for %q: %v\nbody: %s", query, err, w.Body.String())
+	}
+	return resp
+}
+
+func TestHandleSearchFacetsAreNonEmpty(t *testing.T) {
+	s := newTestServer(t)
+
+	resp := doSearch(t, s, "")
+	if len(resp.Facets.Status) == 0 {
+		t.Errorf("expected a non-empty status facet, got %+v", resp.Facets)
+	}
+	if len(resp.Facets.Year) == 0 {
+		t.Errorf("expected a non-empty year facet, got %+v", resp.Facets)
+	}
+}
+
+func TestHandleSearchStatusAndYearFiltersMatch(t *testing.T) {
+	s := newTestServer(t)
+
+	if resp := doSearch(t, s, "status=proposed"); resp.Total == 0 {
+		t.Errorf("status=proposed matched no ADRs, want at least one")
+	}
+	if resp := doSearch(t, s, "year=2024"); resp.Total == 0 {
+		t.Errorf("year=2024 matched no ADRs, want at least one")
+	}
+}
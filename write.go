@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"github.com/gorilla/mux"
+	"gopkg.in/yaml.v3"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// requireAuth gates the write endpoints behind a bearer token or HTTP basic
+// auth password, both checked against cfg.AuthToken (populated from an
+// environment variable by config loading). With no token configured the
+// write API is disabled entirely, since there is nothing safe to compare
+// against.
+func (s *Server) requireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if s.cfg.AuthToken == "" {
+			http.Error(w, "write API disabled: no auth token configured", http.StatusForbidden)
+			return
+		}
+
+		if _, pass, ok := req.BasicAuth(); ok && tokensEqual(pass, s.cfg.AuthToken) {
+			next(w, req)
+			return
+		}
+
+		if token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "); token != "" && tokensEqual(token, s.cfg.AuthToken) {
+			next(w, req)
+			return
+		}
+
+		w.Header().Set("WWW-Authenticate", `Basic realm="adr"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}
+}
+
+func tokensEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slugify turns a title into the dash-separated slug used in ADR filenames.
+func slugify(title string) string {
+	slug := nonSlugChars.ReplaceAllString(strings.ToLower(title), "-")
+	return strings.Trim(slug, "-")
+}
+
+// nextNumber returns one past the highest Number among the existing ADRs.
+func (s *Server) nextNumber(ctx context.Context) (int, error) {
+	docs, err := s.loadDocuments(ctx)
+	if err != nil {
+		return 0, err
+	}
+	next := 1
+	for _, d := range docs {
+		if d.Number >= next {
+			next = d.Number + 1
+		}
+	}
+	return next, nil
+}
+
+// writeDocumentFile serializes d's frontmatter and body to cfg.BaseDir,
+// overwriting any existing file of the same name.
+func (s *Server) writeDocumentFile(d Document) error {
+	front, err := yaml.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(front)
+	buf.WriteString("---\n")
+	buf.WriteString(d.Body)
+
+	return os.WriteFile(filepath.Join(s.cfg.BaseDir, d.Filename), buf.Bytes(), 0o644)
+}
+
+// reindexDocument writes d to disk and updates its entry in the search
+// index to match.
+func (s *Server) reindexDocument(ctx context.Context, d Document) error {
+	if err := s.writeDocumentFile(d); err != nil {
+		return err
+	}
+	info, err := os.Stat(filepath.Join(s.cfg.BaseDir, d.Filename))
+	if err != nil {
+		return err
+	}
+	return s.idx.UpdateOne(ctx, indexID(d.Filename), d, info.ModTime())
+}
+
+// rollbackDocument undoes a reindexDocument call for d, removing both its
+// file and its index entry. Used by handleSupersede to keep its two writes
+// transactional: if the second one fails, the first must not be left
+// behind half-applied.
+func (s *Server) rollbackDocument(ctx context.Context, d Document) {
+	if err := os.Remove(filepath.Join(s.cfg.BaseDir, d.Filename)); err != nil {
+		s.log.Error("rollback: failed to remove file", "file", d.Filename, "error", err)
+	}
+	if err := s.idx.DeleteOne(ctx, indexID(d.Filename)); err != nil {
+		s.log.Error("rollback: failed to remove from index", "file", d.Filename, "error", err)
+	}
+}
+
+// handleNew creates a new ADR from form fields "title" and "body", assigns
+// it the next Number, and indexes it with status "proposed".
+func (s *Server) handleNew(w http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	title := req.PostForm.Get("title")
+	if title == "" {
+		http.Error(w, "title is required", http.StatusBadRequest)
+		return
+	}
+
+	number, err := s.nextNumber(req.Context())
+	if err != nil {
+		panic(err)
+	}
+
+	d := Document{
+		Filename: fmt.Sprintf("%04d-%s.yaml", number, slugify(title)),
+		Number:   number,
+		Title:    title,
+		Date:     time.Now().UTC(),
+		Status:   "proposed",
+		Body:     req.PostForm.Get("body"),
+	}
+
+	if err := s.reindexDocument(req.Context(), d); err != nil {
+		panic(err)
+	}
+
+	http.Redirect(w, req, "/"+d.Filename, http.StatusSeeOther)
+}
+
+// handleEdit updates an existing ADR's title, status and/or body from form
+// fields of the same name, leaving any field that is absent or empty
+// untouched.
+func (s *Server) handleEdit(w http.ResponseWriter, req *http.Request) {
+	item, ok := mux.Vars(req)["item"]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err := req.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	d, err := s.loadDocument(req.Context(), item)
+	if err != nil {
+		panic(err)
+	}
+
+	if title := req.PostForm.Get("title"); title != "" {
+		d.Title = title
+	}
+	if status := req.PostForm.Get("status"); status != "" {
+		d.Status = status
+	}
+	if body := req.PostForm.Get("body"); body != "" {
+		d.Body = body
+	}
+
+	if err := s.reindexDocument(req.Context(), d); err != nil {
+		panic(err)
+	}
+
+	http.Redirect(w, req, "/"+d.Filename, http.StatusSeeOther)
+}
+
+// handleSupersede creates a new ADR (from form fields "title" and "body",
+// like handleNew) that supersedes item: the new document's Supersedes
+// points at item, item's SupersededBy points at the new document, and
+// item's Status becomes "superseded". The two writes are treated as one
+// transaction: if writing the new document fails, the old one is left
+// untouched; if updating the old document fails after the new one was
+// already written, the new document is rolled back and the old one is
+// restored to the pre-supersede content it was loaded with, rather than
+// either being left in a half-applied state.
+func (s *Server) handleSupersede(w http.ResponseWriter, req *http.Request) {
+	item, ok := mux.Vars(req)["item"]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err := req.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	title := req.PostForm.Get("title")
+	if title == "" {
+		http.Error(w, "title is required", http.StatusBadRequest)
+		return
+	}
+
+	original, err := s.loadDocument(req.Context(), item)
+	if err != nil {
+		panic(err)
+	}
+
+	number, err := s.nextNumber(req.Context())
+	if err != nil {
+		panic(err)
+	}
+
+	replacement := Document{
+		Filename:   fmt.Sprintf("%04d-%s.yaml", number, slugify(title)),
+		Number:     number,
+		Title:      title,
+		Date:       time.Now().UTC(),
+		Status:     "proposed",
+		Body:       req.PostForm.Get("body"),
+		Supersedes: original.Filename,
+	}
+	if err := s.reindexDocument(req.Context(), replacement); err != nil {
+		panic(err)
+	}
+
+	superseded := original
+	superseded.Status = "superseded"
+	superseded.SupersededBy = replacement.Filename
+	if err := s.reindexDocument(req.Context(), superseded); err != nil {
+		s.rollbackDocument(req.Context(), replacement)
+		if restoreErr := s.reindexDocument(req.Context(), original); restoreErr != nil {
+			s.log.Error("rollback: failed to restore pre-supersede document", "file", original.Filename, "error", restoreErr)
+		}
+		panic(err)
+	}
+
+	http.Redirect(w, req, "/"+replacement.Filename, http.StatusSeeOther)
+}